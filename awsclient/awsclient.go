@@ -11,6 +11,7 @@ import (
 	"regexp"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 )
@@ -28,9 +29,22 @@ type AWSClient struct {
 	secrets       map[string]CacheItem[string]
 	sessionsMutex sync.Mutex
 	servicesMutex sync.Mutex
-	secretsMutex  sync.Mutex
+	// secretsCacheMutex protege apenas o acesso ao mapa secrets; a busca de
+	// um segredo específico é serializada por secretsKeyLocks, para que uma
+	// consulta em andamento não bloqueie a leitura de outros identificadores.
+	secretsCacheMutex sync.RWMutex
+	secretsKeyLocks   keyedMutex
 	profile				string
 	defaultRegion string
+	// EndpointOverrides mapeia região -> URL de endpoint do Secrets Manager,
+	// para uso contra VPC endpoints privados, LocalStack, etc.
+	EndpointOverrides map[string]string
+	// SecretsManagerEndpoint é usado como endpoint padrão quando não há uma
+	// entrada específica da região em EndpointOverrides.
+	SecretsManagerEndpoint string
+	// maxRetries é o número de novas tentativas em caso de throttling/5xx ao
+	// consultar o Secrets Manager.
+	maxRetries int
 }
 
 // Expressões regulares usadas para avaliar identificadores de
@@ -43,16 +57,30 @@ var (
 )
 
 // Cria uma nova instância de AWSClient.
-func NewAWSClient(profile, defaultRegion string) *AWSClient {
+func NewAWSClient(profile, defaultRegion string, endpointOverrides map[string]string, secretsManagerEndpoint string, maxRetries int) *AWSClient {
 	return &AWSClient{
 		sessions: make(map[string]CacheItem[*session.Session]),
 		services: make(map[string]CacheItem[*secretsmanager.SecretsManager]),
 		secrets:  make(map[string]CacheItem[string]),
 		profile: profile,
 		defaultRegion: defaultRegion,
+		EndpointOverrides: endpointOverrides,
+		SecretsManagerEndpoint: secretsManagerEndpoint,
+		maxRetries: maxRetries,
 	}
 }
 
+// Resolve o endpoint do Secrets Manager a ser usado para a região informada:
+// prioriza uma entrada específica em EndpointOverrides e, na ausência desta,
+// recorre ao SecretsManagerEndpoint global. Retorna "" quando nenhum dos dois
+// estiver configurado, mantendo o endpoint padrão do SDK.
+func (client *AWSClient) resolveEndpoint(region string) string {
+	if endpoint, exists := client.EndpointOverrides[region]; exists && endpoint != "" {
+		return endpoint
+	}
+	return client.SecretsManagerEndpoint
+}
+
 // Retorna uma sessão com a AWS.
 // Se a sessão foi criada anteriormente, retorna a referência existente, caso
 // contrário cria uma nova sessão, armazena sua referência a retorna ao chamador.
@@ -69,7 +97,7 @@ func (client *AWSClient) getSession(region string) (*session.Session, error) {
 		sessionOptions = session.Options{
 			Profile: client.profile,
 			SharedConfigState: session.SharedConfigEnable,
-		}	
+		}
 	} else {
 		sessionOptions = session.Options{
 			Profile: client.profile,
@@ -88,6 +116,14 @@ func (client *AWSClient) getSession(region string) (*session.Session, error) {
 
 	if cacheItem.err != nil {
 		cacheItem.err = fmt.Errorf("não foi possível criar sessão para a região %s. %w", region, err)
+		client.sessions[region] = cacheItem
+		return cacheItem.item, cacheItem.err
+	}
+
+	// Perfis configurados com sso_session/sso_start_url/sso_region autenticam
+	// via bearer token de uma sessão SSO, em vez de credenciais estáticas.
+	if ssoConfig, ok, ssoErr := readSSOProfileConfig(client.profile); ssoErr == nil && ok {
+		item.Config.Credentials = credentials.NewCredentials(newSSOBearerTokenProvider(item, ssoConfig.sessionName, ssoConfig.region, ssoConfig.accountID, ssoConfig.roleName))
 	}
 
 	if _, err := item.Config.Credentials.Get(); err != nil {
@@ -117,6 +153,8 @@ func (client *AWSClient) getService(region string) (*secretsmanager.SecretsManag
 	session, err := client.getSession(region)
 	if err != nil {
 		cacheItem.err = fmt.Errorf("não foi possível obter o client para o secrect manager. %w", err)
+	} else if endpoint := client.resolveEndpoint(region); endpoint != "" {
+		cacheItem.item = secretsmanager.New(session, aws.NewConfig().WithEndpoint(endpoint))
 	} else {
 		cacheItem.item = secretsmanager.New(session)
 	}
@@ -151,11 +189,17 @@ func (client *AWSClient) GetSecret(identifier string) (string, error) {
 		return "", fmt.Errorf("identificador '%s' não é válido", identifier)
 	}
 
-	client.secretsMutex.Lock()
-	defer client.secretsMutex.Unlock()
+	if item, found := client.lookupSecret(identifier); found {
+		return item.item, item.err
+	}
 
-	if cacheItem, exists := client.secrets[identifier]; exists {
-		return cacheItem.item, cacheItem.err
+	unlock := client.secretsKeyLocks.Lock(identifier)
+	defer unlock()
+
+	// Outra goroutine pode ter resolvido este identificador enquanto
+	// aguardávamos o lock específico dele.
+	if item, found := client.lookupSecret(identifier); found {
+		return item.item, item.err
 	}
 
 	cacheItem := CacheItem[string]{
@@ -168,12 +212,9 @@ func (client *AWSClient) GetSecret(identifier string) (string, error) {
 	if err != nil {
 		cacheItem.err = fmt.Errorf("não foi possível obter o segredo: %w", err)
 	} else {
-		result, err := service.GetSecretValue(
-			&secretsmanager.GetSecretValueInput{
-				SecretId: aws.String(identifier),
-			})
+		result, err := client.getSecretValueWithRetry(service, identifier)
 
-		if result.SecretString != nil {
+		if result != nil && result.SecretString != nil {
 			cacheItem.item = *result.SecretString
 		} else {
 			if err == nil {
@@ -184,6 +225,18 @@ func (client *AWSClient) GetSecret(identifier string) (string, error) {
 		}
 	}
 
+	client.secretsCacheMutex.Lock()
 	client.secrets[identifier] = cacheItem
+	client.secretsCacheMutex.Unlock()
+
 	return cacheItem.item, cacheItem.err
 }
+
+// lookupSecret consulta o cache de segredos já resolvidos.
+func (client *AWSClient) lookupSecret(identifier string) (CacheItem[string], bool) {
+	client.secretsCacheMutex.RLock()
+	defer client.secretsCacheMutex.RUnlock()
+
+	item, exists := client.secrets[identifier]
+	return item, exists
+}
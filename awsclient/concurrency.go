@@ -0,0 +1,30 @@
+// Este arquivo implementa um lock por chave, usado para que chamadas
+// concorrentes a GetSecret para identificadores diferentes não se bloqueiem
+// mutuamente, enquanto chamadas para o mesmo identificador continuam
+// serializadas entre si.
+package awsclient
+
+import "sync"
+
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Lock adquire o lock associado à chave informada, criando-o se necessário,
+// e retorna a função que o libera.
+func (k *keyedMutex) Lock(key string) (unlock func()) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*sync.Mutex)
+	}
+	keyLock, exists := k.locks[key]
+	if !exists {
+		keyLock = &sync.Mutex{}
+		k.locks[key] = keyLock
+	}
+	k.mu.Unlock()
+
+	keyLock.Lock()
+	return keyLock.Unlock
+}
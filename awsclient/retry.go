@@ -0,0 +1,78 @@
+// Este arquivo implementa a política de novas tentativas usada ao consultar
+// o Secrets Manager: backoff exponencial com jitter para erros de
+// throttling/5xx, nos mesmos moldes do retryer padrão do AWS SDK.
+package awsclient
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+const (
+	retryBaseDelay = 100 * time.Millisecond
+	retryMaxDelay  = 20 * time.Second
+)
+
+// getSecretValueWithRetry chama GetSecretValue, tentando novamente em caso de
+// erros de throttling/5xx até client.maxRetries vezes.
+func (client *AWSClient) getSecretValueWithRetry(service *secretsmanager.SecretsManager, identifier string) (*secretsmanager.GetSecretValueOutput, error) {
+	var result *secretsmanager.GetSecretValueOutput
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		result, err = service.GetSecretValue(&secretsmanager.GetSecretValueInput{
+			SecretId: &identifier,
+		})
+
+		if err == nil || attempt >= client.maxRetries || !isRetryableError(err) {
+			return result, err
+		}
+
+		time.Sleep(retryBackoff(attempt))
+	}
+}
+
+// isRetryableError identifica os erros para os quais vale tentar novamente:
+// throttling explícito do Secrets Manager e falhas 5xx do serviço.
+func isRetryableError(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+
+	switch awsErr.Code() {
+	case "ThrottlingException", "RequestLimitExceeded":
+		return true
+	}
+
+	var requestErr awserr.RequestFailure
+	if errors.As(err, &requestErr) {
+		return requestErr.StatusCode() >= 500
+	}
+
+	return false
+}
+
+// retryBackoffMaxShift limita o expoente usado no cálculo do backoff: a
+// partir dele o atraso já excede retryMaxDelay, então deslocamentos maiores
+// só arriscariam estourar o intervalo representável por time.Duration.
+const retryBackoffMaxShift = 16
+
+// retryBackoff calcula o tempo de espera antes da próxima tentativa: dobra a
+// cada tentativa até um teto, com jitter total para evitar que tentativas
+// concorrentes se sincronizem.
+func retryBackoff(attempt int) time.Duration {
+	if attempt > retryBackoffMaxShift {
+		attempt = retryBackoffMaxShift
+	}
+
+	delay := retryBaseDelay * time.Duration(1<<uint(attempt))
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
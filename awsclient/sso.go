@@ -0,0 +1,394 @@
+// Este arquivo implementa suporte a autenticação via AWS IAM Identity Center
+// (SSO) usando perfis configurados com sso_session/sso_start_url/sso_region
+// no ~/.aws/config, incluindo leitura do cache de token local, renovação via
+// refresh token e o fluxo de autorização por dispositivo (device code).
+package awsclient
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sso"
+	"github.com/aws/aws-sdk-go/service/ssooidc"
+)
+
+// Representa o conteúdo de um arquivo de cache de token SSO, conforme
+// gravado pelo `aws sso login` em ~/.aws/sso/cache/<sha1(sessão)>.json.
+type ssoTokenCache struct {
+	AccessToken           string    `json:"accessToken"`
+	ExpiresAt             time.Time `json:"expiresAt"`
+	RefreshToken          string    `json:"refreshToken,omitempty"`
+	ClientID              string    `json:"clientId"`
+	ClientSecret          string    `json:"clientSecret"`
+	RegistrationExpiresAt time.Time `json:"registrationExpiresAt"`
+	Region                string    `json:"region,omitempty"`
+	StartURL              string    `json:"startUrl,omitempty"`
+}
+
+// Configuração SSO de um perfil, extraída do ~/.aws/config.
+type ssoProfileConfig struct {
+	sessionName string
+	startURL    string
+	region      string
+	accountID   string
+	roleName    string
+}
+
+var profileHeaderRegex = regexp.MustCompile(`^\[profile\s+([^\]]+)\]$|^\[([^\]]+)\]$`)
+
+// Calcula o nome do arquivo de cache para uma sessão SSO, seguindo a mesma
+// convenção usada pela AWS CLI: sha1 do nome da sessão.
+func ssoCacheKey(sessionName string) string {
+	sum := sha1.Sum([]byte(sessionName))
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+func ssoCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("não foi possível determinar o diretório home do usuário. %w", err)
+	}
+	return filepath.Join(home, ".aws", "sso", "cache"), nil
+}
+
+// Lê, a partir do ~/.aws/config, a configuração sso_session/sso_start_url/
+// sso_region associada ao perfil informado. Retorna ok=false se o perfil não
+// estiver configurado para autenticação via SSO baseada em sessão.
+func readSSOProfileConfig(profile string) (cfg ssoProfileConfig, ok bool, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return cfg, false, fmt.Errorf("não foi possível determinar o diretório home do usuário. %w", err)
+	}
+
+	file, err := os.Open(filepath.Join(home, ".aws", "config"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, false, nil
+		}
+		return cfg, false, fmt.Errorf("não foi possível ler o ~/.aws/config. %w", err)
+	}
+	defer file.Close()
+
+	sections := map[string]map[string]string{}
+	currentSection := ""
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if matches := profileHeaderRegex.FindStringSubmatch(line); matches != nil {
+			name := matches[1]
+			if name == "" {
+				name = matches[2]
+			}
+			currentSection = strings.TrimSpace(name)
+			if _, exists := sections[currentSection]; !exists {
+				sections[currentSection] = map[string]string{}
+			}
+			continue
+		}
+
+		if currentSection == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		sections[currentSection][strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return cfg, false, fmt.Errorf("não foi possível interpretar o ~/.aws/config. %w", err)
+	}
+
+	profileSection, exists := sections[profile]
+	if !exists {
+		return cfg, false, nil
+	}
+
+	sessionName := profileSection["sso_session"]
+	startURL := profileSection["sso_start_url"]
+	region := profileSection["sso_region"]
+	accountID := profileSection["sso_account_id"]
+	roleName := profileSection["sso_role_name"]
+
+	if sessionSection, exists := sections["sso-session "+sessionName]; sessionName != "" && exists {
+		if startURL == "" {
+			startURL = sessionSection["sso_start_url"]
+		}
+		if region == "" {
+			region = sessionSection["sso_region"]
+		}
+	}
+
+	if sessionName == "" || startURL == "" || region == "" || accountID == "" || roleName == "" {
+		return cfg, false, nil
+	}
+
+	return ssoProfileConfig{
+		sessionName: sessionName,
+		startURL:    startURL,
+		region:      region,
+		accountID:   accountID,
+		roleName:    roleName,
+	}, true, nil
+}
+
+// Lê o token SSO em cache para a sessão informada.
+func loadSSOToken(sessionName string) (*ssoTokenCache, error) {
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ssoCacheKey(sessionName)))
+	if err != nil {
+		return nil, fmt.Errorf("token SSO da sessão '%s' não encontrado em cache. %w", sessionName, err)
+	}
+
+	var token ssoTokenCache
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("não foi possível interpretar o cache de token SSO da sessão '%s'. %w", sessionName, err)
+	}
+
+	return &token, nil
+}
+
+// Regrava o cache de token de forma atômica (grava em arquivo temporário e
+// renomeia em seguida), evitando que uma leitura concorrente veja um
+// arquivo parcialmente escrito.
+func saveSSOToken(sessionName string, token *ssoTokenCache) error {
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("não foi possível criar o diretório de cache SSO '%s'. %w", dir, err)
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("não foi possível serializar o token SSO da sessão '%s'. %w", sessionName, err)
+	}
+
+	tmpFile, err := os.CreateTemp(dir, "sso-token-*.tmp")
+	if err != nil {
+		return fmt.Errorf("não foi possível criar arquivo temporário para o cache SSO. %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("não foi possível gravar o cache de token SSO. %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("não foi possível finalizar a gravação do cache de token SSO. %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), filepath.Join(dir, ssoCacheKey(sessionName))); err != nil {
+		return fmt.Errorf("não foi possível atualizar o cache de token SSO da sessão '%s'. %w", sessionName, err)
+	}
+
+	return nil
+}
+
+// Renova um token SSO expirado usando o refresh token, através do fluxo
+// sso-oidc:CreateToken com grant_type=refresh_token.
+func refreshSSOToken(oidcClient *ssooidc.SSOOIDC, sessionName string, token *ssoTokenCache) (*ssoTokenCache, error) {
+	if token.RefreshToken == "" {
+		return nil, fmt.Errorf("token SSO da sessão '%s' expirou e não possui refresh token", sessionName)
+	}
+
+	output, err := oidcClient.CreateToken(&ssooidc.CreateTokenInput{
+		ClientId:     aws.String(token.ClientID),
+		ClientSecret: aws.String(token.ClientSecret),
+		GrantType:    aws.String("refresh_token"),
+		RefreshToken: aws.String(token.RefreshToken),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível renovar o token SSO da sessão '%s'. %w", sessionName, err)
+	}
+
+	renewed := *token
+	renewed.AccessToken = aws.StringValue(output.AccessToken)
+	renewed.ExpiresAt = time.Now().Add(time.Duration(aws.Int64Value(output.ExpiresIn)) * time.Second)
+	if output.RefreshToken != nil {
+		renewed.RefreshToken = aws.StringValue(output.RefreshToken)
+	}
+
+	if err := saveSSOToken(sessionName, &renewed); err != nil {
+		return nil, err
+	}
+
+	return &renewed, nil
+}
+
+// Implementa credentials.Provider trocando o bearer token de uma sessão SSO
+// por credenciais de papel reais via sso:GetRoleCredentials (um token de
+// acesso do IAM Identity Center não é, por si só, uma credencial SigV4).
+// Renova o bearer token via refresh token sempre que necessário, e nunca
+// mantém em uso credenciais além de sua expiração, nos mesmos moldes do
+// aws/credentials/ssocreds.
+type ssoBearerTokenProvider struct {
+	credentials.Expiry
+
+	sessionName  string
+	region       string
+	accountID    string
+	roleName     string
+	oidcClient   *ssooidc.SSOOIDC
+	portalClient *sso.SSO
+}
+
+func newSSOBearerTokenProvider(sess *session.Session, sessionName, region, accountID, roleName string) *ssoBearerTokenProvider {
+	return &ssoBearerTokenProvider{
+		sessionName:  sessionName,
+		region:       region,
+		accountID:    accountID,
+		roleName:     roleName,
+		oidcClient:   ssooidc.New(sess, aws.NewConfig().WithRegion(region)),
+		portalClient: sso.New(sess, aws.NewConfig().WithRegion(region)),
+	}
+}
+
+func (p *ssoBearerTokenProvider) Retrieve() (credentials.Value, error) {
+	token, err := loadSSOToken(p.sessionName)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		token, err = refreshSSOToken(p.oidcClient, p.sessionName, token)
+		if err != nil {
+			return credentials.Value{}, err
+		}
+	}
+
+	output, err := p.portalClient.GetRoleCredentials(&sso.GetRoleCredentialsInput{
+		AccessToken: aws.String(token.AccessToken),
+		AccountId:   aws.String(p.accountID),
+		RoleName:    aws.String(p.roleName),
+	})
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("não foi possível obter credenciais de papel via SSO para a conta '%s', papel '%s'. %w", p.accountID, p.roleName, err)
+	}
+
+	p.SetExpiration(time.UnixMilli(aws.Int64Value(output.RoleCredentials.Expiration)), 0)
+
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(output.RoleCredentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(output.RoleCredentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(output.RoleCredentials.SessionToken),
+		ProviderName:    "SSOBearerTokenProvider",
+	}, nil
+}
+
+// DeviceLogin executa o fluxo de autorização por dispositivo (RegisterClient
+// -> StartDeviceAuthorization -> CreateToken) para o perfil informado,
+// imprimindo a URL de verificação para o usuário e aguardando a aprovação.
+// É acionado pela flag -sso-login, e só inicia o fluxo quando o token em
+// cache está ausente ou expirado e não pode ser renovado via refresh token.
+func (client *AWSClient) DeviceLogin() error {
+	cfg, ok, err := readSSOProfileConfig(client.profile)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("perfil '%s' não está configurado para autenticação via SSO (sso_session)", client.profile)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{Region: aws.String(cfg.region)},
+	})
+	if err != nil {
+		return fmt.Errorf("não foi possível iniciar o login SSO. %w", err)
+	}
+
+	oidcClient := ssooidc.New(sess, aws.NewConfig().WithRegion(cfg.region))
+
+	// Um token em cache ainda válido, ou que possa ser renovado via refresh
+	// token, dispensa um novo login.
+	if cached, err := loadSSOToken(cfg.sessionName); err == nil {
+		if time.Now().Before(cached.ExpiresAt) {
+			return nil
+		}
+		if _, err := refreshSSOToken(oidcClient, cfg.sessionName, cached); err == nil {
+			return nil
+		}
+	}
+
+	registration, err := oidcClient.RegisterClient(&ssooidc.RegisterClientInput{
+		ClientName: aws.String("envconfig"),
+		ClientType: aws.String("public"),
+	})
+	if err != nil {
+		return fmt.Errorf("não foi possível registrar o cliente SSO. %w", err)
+	}
+
+	authorization, err := oidcClient.StartDeviceAuthorization(&ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     registration.ClientId,
+		ClientSecret: registration.ClientSecret,
+		StartUrl:     aws.String(cfg.startURL),
+	})
+	if err != nil {
+		return fmt.Errorf("não foi possível iniciar a autorização por dispositivo. %w", err)
+	}
+
+	fmt.Printf("Acesse %s e confirme o código %s para concluir o login.\n",
+		aws.StringValue(authorization.VerificationUriComplete), aws.StringValue(authorization.UserCode))
+
+	interval := time.Duration(aws.Int64Value(authorization.Interval)) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(aws.Int64Value(authorization.ExpiresIn)) * time.Second)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		output, err := oidcClient.CreateToken(&ssooidc.CreateTokenInput{
+			ClientId:     registration.ClientId,
+			ClientSecret: registration.ClientSecret,
+			GrantType:    aws.String("urn:ietf:params:oauth:grant-type:device_code"),
+			DeviceCode:   authorization.DeviceCode,
+		})
+		if err != nil {
+			if reqErr, isAWSErr := err.(interface{ Code() string }); isAWSErr && reqErr.Code() == ssooidc.ErrCodeAuthorizationPendingException {
+				continue
+			}
+			return fmt.Errorf("não foi possível concluir o login SSO. %w", err)
+		}
+
+		token := ssoTokenCache{
+			AccessToken:           aws.StringValue(output.AccessToken),
+			ExpiresAt:             time.Now().Add(time.Duration(aws.Int64Value(output.ExpiresIn)) * time.Second),
+			RefreshToken:          aws.StringValue(output.RefreshToken),
+			ClientID:              aws.StringValue(registration.ClientId),
+			ClientSecret:          aws.StringValue(registration.ClientSecret),
+			RegistrationExpiresAt: time.Unix(aws.Int64Value(registration.ClientSecretExpiresAt), 0),
+			Region:                cfg.region,
+			StartURL:              cfg.startURL,
+		}
+
+		return saveSSOToken(cfg.sessionName, &token)
+	}
+
+	return fmt.Errorf("tempo para aprovação do login SSO esgotado")
+}
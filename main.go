@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -10,8 +12,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
+	"sync"
 
 	"envconfig/awsclient"
+	"envconfig/secretstore"
 )
 
 // Versão será injetada no build.
@@ -22,10 +27,314 @@ var Version = "indefinida"
 var (
 	commentRegex     = regexp.MustCompile(`^\s*[#\/*]`)
 	envVarRegex      = regexp.MustCompile(`{?\${?([A-Za-z_{][A-Za-z0-9_]*)}?`)
-	placeholderRegex = regexp.MustCompile(`\{\{?([\w/:+_=.@-]+)(\[([\w]+)\])?\}?\}`)
+	placeholderRegex = regexp.MustCompile(`\{\{?([\w/:+_=.@#-]+)(\[([\w]+)\])?\}?\}`)
 )
 
-func processTemplateFile(inputFilePath string, outputFilePath string, profile string, region string) error {
+// maxAllowedRetries limita o valor aceito para -max-retries: valores maiores
+// são reduzidos a ele, e valores negativos a zero.
+const maxAllowedRetries = 10
+
+// endpointOverridesFlag implementa flag.Value para permitir múltiplas
+// ocorrências de -endpoint-region, cada uma no formato "região=url".
+type endpointOverridesFlag map[string]string
+
+func (f endpointOverridesFlag) String() string {
+	return fmt.Sprintf("%v", map[string]string(f))
+}
+
+func (f endpointOverridesFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("formato inválido '%s'. Esperado região=url", value)
+	}
+	f[parts[0]] = parts[1]
+	return nil
+}
+
+// ProcessOptions agrupa as opções de processamento do template que não
+// dizem respeito à resolução de segredos em si.
+type ProcessOptions struct {
+	Parallelism int
+	// Format controla como a saída é produzida: "text" (padrão, substituição
+	// direta no arquivo), "json"/"yaml" (um objeto/mapeamento chave-valor a
+	// partir de linhas KEY={{...}}) ou "env" (linhas `export KEY=valor`).
+	Format string
+	// DryRun, quando true, não grava o arquivo de saída: em vez disso emite,
+	// para cada placeholder, um registro JSON de auditoria sem o valor em
+	// texto plano.
+	DryRun bool
+	// Mask evita que identificadores de segredos apareçam nas mensagens de
+	// erro registradas em stderr.
+	Mask bool
+}
+
+// Identifica um placeholder de segredo já decomposto em identificador e
+// subchave, usado para deduplicar consultas repetidas no mesmo arquivo.
+type secretLookupKey struct {
+	id     string
+	subkey string
+}
+
+type secretLookupResult struct {
+	value string
+	err   error
+}
+
+// resolveSecrets busca, concorrentemente e com paralelismo limitado por
+// `parallelism`, o valor de cada placeholder único em `keys`.
+func resolveSecrets(secrets *secretstore.Registry, keys map[secretLookupKey]struct{}, parallelism int) map[secretLookupKey]secretLookupResult {
+	results := make(map[secretLookupKey]secretLookupResult, len(keys))
+	var resultsMutex sync.Mutex
+
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for key := range keys {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(key secretLookupKey) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			value, err := secrets.GetSecret(key.id)
+			if err == nil && key.subkey != "" {
+				var secretMap map[string]string
+				if jsonErr := json.Unmarshal([]byte(value), &secretMap); jsonErr != nil {
+					err = fmt.Errorf("erro ao parsear JSON: %w", jsonErr)
+				} else if subvalue, exists := secretMap[key.subkey]; exists {
+					value = subvalue
+				} else {
+					err = fmt.Errorf("chave '%s' não encontrada", key.subkey)
+				}
+			}
+
+			resultsMutex.Lock()
+			results[key] = secretLookupResult{value: value, err: err}
+			resultsMutex.Unlock()
+		}(key)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// logResolutionError registra no log um segredo que não pôde ser resolvido,
+// omitindo o identificador quando mask estiver habilitado.
+func logResolutionError(err error, mask bool) {
+	if mask {
+		log.Println("não foi possível resolver um segredo (detalhes omitidos por -mask)")
+		return
+	}
+	log.Println(err)
+}
+
+// sha256Hex calcula o sha256 de um valor resolvido, para uso em modo de
+// auditoria sem nunca expor o valor em texto plano.
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitPlaceholderBackend identifica o backend de um identificador de segredo
+// a partir de seu prefixo, retornando também o identificador sem o prefixo.
+// Assume o Secrets Manager quando não houver um prefixo reconhecido
+// (comportamento histórico), caso em que o identificador é retornado intacto.
+func splitPlaceholderBackend(identifier string) (backend, id string) {
+	if idx := strings.Index(identifier, ":"); idx > 0 {
+		switch identifier[:idx] {
+		case "sm", "ssm", "vault", "file":
+			return identifier[:idx], identifier[idx+1:]
+		}
+	}
+	return "secretsmanager", identifier
+}
+
+// dryRunRecord é o registro de auditoria emitido em modo -dry-run para cada
+// placeholder encontrado, nunca contendo o valor resolvido em texto plano.
+type dryRunRecord struct {
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	Placeholder string `json:"placeholder"`
+	Backend     string `json:"backend"`
+	Resolved    bool   `json:"resolved"`
+	Source      string `json:"source,omitempty"`
+	ValueSHA256 string `json:"value_sha256,omitempty"`
+}
+
+// emitDryRun escreve, linha a linha na ordem do arquivo, um registro JSON por
+// placeholder, para que operadores possam auditar de onde cada valor viria
+// sem realizar a substituição. Em cada linha vêm primeiro os placeholders de
+// variáveis de ambiente definidas e depois os de segredo; um placeholder de
+// variável de ambiente não definida não gera registro de ambiente.
+func emitDryRun(writer *bufio.Writer, lines []string, results map[secretLookupKey]secretLookupResult) error {
+	encoder := json.NewEncoder(writer)
+
+	for lineNumber, line := range lines {
+		if commentRegex.MatchString(line) {
+			continue
+		}
+
+		for _, match := range envVarRegex.FindAllStringSubmatchIndex(line, -1) {
+			name := line[match[2]:match[3]]
+			value := os.Getenv(name)
+			if value == "" {
+				continue
+			}
+
+			record := dryRunRecord{
+				Line:        lineNumber + 1,
+				Column:      match[0] + 1,
+				Placeholder: line[match[0]:match[1]],
+				Backend:     "env",
+				Resolved:    true,
+				Source:      "env",
+				ValueSHA256: sha256Hex(value),
+			}
+			if err := encoder.Encode(record); err != nil {
+				return fmt.Errorf("erro ao escrever o registro de auditoria: %w", err)
+			}
+		}
+
+		envResolvedLine := resolveEnvVars(line)
+		for _, match := range placeholderRegex.FindAllStringSubmatchIndex(envResolvedLine, -1) {
+			identifier := envResolvedLine[match[2]:match[3]]
+			subkey := ""
+			if match[6] != -1 {
+				subkey = envResolvedLine[match[6]:match[7]]
+			}
+
+			result := results[secretLookupKey{id: identifier, subkey: subkey}]
+			backend, bareID := splitPlaceholderBackend(identifier)
+			record := dryRunRecord{
+				Line:        lineNumber + 1,
+				Column:      match[0] + 1,
+				Placeholder: envResolvedLine[match[0]:match[1]],
+				Backend:     backend,
+			}
+
+			if result.err == nil {
+				record.Resolved = true
+				record.Source = fmt.Sprintf("%s:%s", backend, bareID)
+				record.ValueSHA256 = sha256Hex(result.value)
+			}
+
+			if err := encoder.Encode(record); err != nil {
+				return fmt.Errorf("erro ao escrever o registro de auditoria: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveEnvVars substitui, em uma linha, cada placeholder de variável de
+// ambiente ($VAR ou ${VAR}) pelo seu valor, quando definida; placeholders
+// cuja variável não está definida são devolvidos inalterados. É usada antes
+// de procurar por placeholders de segredo, para que um placeholder satisfeito
+// pela variável de ambiente não seja tratado como um segredo.
+func resolveEnvVars(line string) string {
+	return envVarRegex.ReplaceAllStringFunc(line, func(placeholder string) string {
+		matches := envVarRegex.FindStringSubmatch(placeholder)
+		if envVarValue := os.Getenv(matches[1]); envVarValue != "" {
+			return envVarValue
+		}
+		return matches[0]
+	})
+}
+
+// substituteLine aplica, nesta ordem, as substituições por variável de
+// ambiente e por segredo sobre uma linha (ou trecho de linha), retornando o
+// resultado e a quantidade de placeholders que não puderam ser resolvidos.
+func substituteLine(line string, results map[secretLookupKey]secretLookupResult, mask bool) (string, int) {
+	failed := 0
+
+	processedLine := resolveEnvVars(line)
+
+	processedLine = placeholderRegex.ReplaceAllStringFunc(processedLine, func(placeholder string) string {
+		matches := placeholderRegex.FindStringSubmatch(placeholder)
+		result := results[secretLookupKey{id: matches[1], subkey: matches[3]}]
+
+		if result.err != nil {
+			failed++
+			logResolutionError(result.err, mask)
+		} else if result.value != "" {
+			return result.value
+		}
+		return matches[0]
+	})
+
+	return processedLine, failed
+}
+
+// quoteValue produz um literal de string entre aspas duplas, usado como
+// escalar YAML.
+func quoteValue(value string) string {
+	escaped := strings.ReplaceAll(value, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+	return `"` + escaped + `"`
+}
+
+// writeStructuredOutput trata o arquivo de entrada como uma lista de linhas
+// KEY={{...}}, resolve o valor de cada uma e grava a saída no formato
+// estruturado solicitado ("json", "yaml" ou "env").
+func writeStructuredOutput(writer *bufio.Writer, format string, lines []string, results map[secretLookupKey]secretLookupResult, mask bool) (int, error) {
+	failed := 0
+	keys := make([]string, 0, len(lines))
+	values := make(map[string]string, len(lines))
+
+	for _, line := range lines {
+		if commentRegex.MatchString(line) || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		key, template, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+
+		value, lineFailed := substituteLine(template, results, mask)
+		failed += lineFailed
+
+		if _, exists := values[key]; !exists {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+
+	switch format {
+	case "json":
+		object := make(map[string]string, len(keys))
+		for _, key := range keys {
+			object[key] = values[key]
+		}
+		data, err := json.MarshalIndent(object, "", "  ")
+		if err != nil {
+			return failed, fmt.Errorf("não foi possível serializar a saída em JSON. %w", err)
+		}
+		fmt.Fprintln(writer, string(data))
+	case "yaml":
+		for _, key := range keys {
+			fmt.Fprintf(writer, "%s: %s\n", key, quoteValue(values[key]))
+		}
+	case "env":
+		for _, key := range keys {
+			// Aspas simples evitam que o shell expanda $, ` e \ no valor.
+			fmt.Fprintf(writer, "export %s='%s'\n", key, strings.ReplaceAll(values[key], `'`, `'\''`))
+		}
+	default:
+		return failed, fmt.Errorf("formato de saída '%s' não suportado", format)
+	}
+
+	return failed, nil
+}
+
+func processTemplateFile(inputFilePath string, outputFilePath string, secrets *secretstore.Registry, opts ProcessOptions) error {
 
 	if _, err := os.Stat(inputFilePath); os.IsNotExist(err) {
 		return fmt.Errorf("arquivo de entrada '%s' não encontrado", inputFilePath)
@@ -37,70 +346,70 @@ func processTemplateFile(inputFilePath string, outputFilePath string, profile st
 	}
 	defer inputFile.Close()
 
+	var lines []string
+	scanner := bufio.NewScanner(inputFile)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("erro ao ler o arquivo de entrada: %w", err)
+	}
+
+	// Primeira passagem: varre todo o arquivo e coleta o conjunto único de
+	// placeholders de segredo, para que cada um seja consultado uma única vez.
+	// Aplica a substituição por variável de ambiente antes de procurar por
+	// placeholders de segredo, na mesma ordem (env primeiro) usada por
+	// substituteLine, para que um placeholder satisfeito pela variável de
+	// ambiente não gere uma consulta espúria ao backend de segredos.
+	uniqueKeys := make(map[secretLookupKey]struct{})
+	for _, line := range lines {
+		if commentRegex.MatchString(line) {
+			continue
+		}
+		for _, matches := range placeholderRegex.FindAllStringSubmatch(resolveEnvVars(line), -1) {
+			uniqueKeys[secretLookupKey{id: matches[1], subkey: matches[3]}] = struct{}{}
+		}
+	}
+
+	// Segunda passagem: resolve os placeholders únicos concorrentemente.
+	results := resolveSecrets(secrets, uniqueKeys, opts.Parallelism)
+
+	if opts.DryRun {
+		writer := bufio.NewWriter(os.Stdout)
+		if err := emitDryRun(writer, lines, results); err != nil {
+			return err
+		}
+		return writer.Flush()
+	}
+
 	outputFile, err := os.Create(outputFilePath)
 	if err != nil {
 		return fmt.Errorf("erro ao criar o arquivo '%s'. %w", outputFilePath, err)
 	}
 	defer outputFile.Close()
 
-	scanner := bufio.NewScanner(inputFile)
 	writer := bufio.NewWriter(outputFile)
-	client := awsclient.NewAWSClient(profile, region)
-	failed := 0 
-
-	for scanner.Scan() {
-		line := scanner.Text()
+	failed := 0
 
-		//Ignora comentários
-		if commentRegex.MatchString(line) {
-			fmt.Fprintln(writer, line)
-			continue
+	if opts.Format != "" && opts.Format != "text" {
+		failed, err = writeStructuredOutput(writer, opts.Format, lines, results, opts.Mask)
+		if err != nil {
+			return err
 		}
+	} else {
+		for _, line := range lines {
 
-		//Processa primeiro as substituições por variáveis de ambiente
-		//Substitui os placeholders pelo valor da variável com o mesmo nome
-		//Se não for encontrado uma variável para o placeholder, o mantém
-		//para a possibilidade de substituir posteriormente com algum segredo.
-		processedLine := envVarRegex.ReplaceAllStringFunc(line, func(placeholder string) string {
-			matches := envVarRegex.FindStringSubmatch(placeholder)
-			envVarValue := os.Getenv(matches[1])
-			if envVarValue != "" {
-				return envVarValue
-			}
-			return matches[0]
-		})
-
-		//Processa em seguida as substituições de secrets
-		//Se não for identificado o valor do segredo para o placeholder, o mantém como na origem
-		processedLine = placeholderRegex.ReplaceAllStringFunc(processedLine, func(placeholder string) string {
-			matches := placeholderRegex.FindStringSubmatch(placeholder)
-			secretValue, err := client.GetSecret(matches[1])
-
-			if err != nil {
-				failed ++
-				log.Println(err)
-			} else if secretValue != "" {
-				if matches[3] == "" {
-					return secretValue
-				} else {
-					var secretMap map[string]string
-					if err := json.Unmarshal([]byte(secretValue), &secretMap); err == nil {
-						if value, exists := secretMap[matches[3]]; exists {
-							return value
-						} else {
-							failed ++
-							log.Printf("chave '%s' não encontrada", matches[3])
-						}
-					} else {
-						failed ++
-						log.Println("erro ao parsear JSON: ", err)
-					}
-				}
+			//Ignora comentários
+			if commentRegex.MatchString(line) {
+				fmt.Fprintln(writer, line)
+				continue
 			}
-			return matches[0]
-		})
 
-		fmt.Fprintln(writer, processedLine)
+			processedLine, lineFailed := substituteLine(line, results, opts.Mask)
+			failed += lineFailed
+
+			fmt.Fprintln(writer, processedLine)
+		}
 	}
 
 	if failed > 0 {
@@ -111,20 +420,29 @@ func processTemplateFile(inputFilePath string, outputFilePath string, profile st
 		return fmt.Errorf("erro ao escrever no arquivo de saída: %w", err)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("erro ao ler o arquivo de entrada: %w", err)
-	}
-
 	return nil
 }
 
 func main() {
 	var profile string
 	var region string
+	var endpoint string
+	var parallel int
+	var maxRetries int
+	var format string
+	endpointRegions := make(endpointOverridesFlag)
 
 	versionFlag := flag.Bool("version", false, "Exibe a versão do software")
+	ssoLoginFlag := flag.Bool("sso-login", false, "Inicia o fluxo de autorização por dispositivo caso o token SSO em cache esteja ausente ou expirado")
+	dryRunFlag := flag.Bool("dry-run", false, "Não grava o arquivo de saída; em vez disso, emite um registro de auditoria por placeholder (sem valores em texto plano)")
+	maskFlag := flag.Bool("mask", false, "Redige identificadores de segredos nas mensagens de erro registradas em stderr")
 	flag.StringVar(&profile, "profile", "default", "Perfil AWS a ser utilizado")
 	flag.StringVar(&region, "region", "", "Região da AWS a ser utilizada")
+	flag.StringVar(&endpoint, "endpoint", "", "Endpoint customizado do Secrets Manager, usado quando nenhuma região específica em -endpoint-region corresponder")
+	flag.Var(endpointRegions, "endpoint-region", "Endpoint customizado do Secrets Manager para uma região específica, no formato região=url. Pode ser repetida")
+	flag.IntVar(&parallel, "parallel", 8, "Número de segredos resolvidos simultaneamente")
+	flag.IntVar(&maxRetries, "max-retries", 3, "Número de novas tentativas em caso de throttling/erro 5xx do Secrets Manager")
+	flag.StringVar(&format, "format", "text", "Formato da saída: text, json, yaml ou env")
 	flag.Parse()
 
 	if *versionFlag {
@@ -132,17 +450,64 @@ func main() {
 		os.Exit(0)
 	}
 
+	switch format {
+	case "text", "json", "yaml", "env":
+	default:
+		fmt.Fprintf(os.Stderr, "Erro: formato '%s' inválido. Use text, json, yaml ou env\n", format)
+		os.Exit(1)
+	}
+
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_SECRETSMANAGER_ENDPOINT")
+	}
+
+	if maxRetries < 0 {
+		maxRetries = 0
+	} else if maxRetries > maxAllowedRetries {
+		maxRetries = maxAllowedRetries
+	}
+
+	client := awsclient.NewAWSClient(profile, region, endpointRegions, endpoint, maxRetries)
+
+	if *ssoLoginFlag {
+		if err := client.DeviceLogin(); err != nil {
+			fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Registro de backends de segredos: "sm"/sem prefixo usa o AWS Secrets
+	// Manager (comportamento histórico), e os demais prefixos habilitam os
+	// backends adicionais suportados pelo placeholder {{backend:id}}.
+	awssmProvider := secretstore.NewAWSSecretsManagerProvider(client)
+	secrets := secretstore.NewRegistry(awssmProvider)
+	secrets.Register("sm", awssmProvider)
+	secrets.Register("ssm", secretstore.NewSSMProvider(profile, region))
+	secrets.Register("vault", secretstore.NewVaultProvider())
+	secrets.Register("file", secretstore.NewFileProvider())
+
 	posArgs := flag.Args()
 
 	if len(posArgs) != 2 {
-		fmt.Println("Uso: " + filepath.Base(os.Args[0]) + " [-profile <profile>] [-region <region>] <inputFilePath> <outputFilePath>")
+		fmt.Println("Uso: " + filepath.Base(os.Args[0]) + " [-profile <profile>] [-region <region>] [-sso-login] [-endpoint <url>] [-endpoint-region região=url] [-parallel <n>] [-max-retries <n>] [-format text|json|yaml|env] [-dry-run] [-mask] <inputFilePath> <outputFilePath>")
 		os.Exit(1)
 	}
 
-	if err := processTemplateFile(posArgs[0], posArgs[1], profile, region); err != nil {
+	opts := ProcessOptions{
+		Parallelism: parallel,
+		Format:      format,
+		DryRun:      *dryRunFlag,
+		Mask:        *maskFlag,
+	}
+
+	if err := processTemplateFile(posArgs[0], posArgs[1], secrets, opts); err != nil {
 		fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("Arquivo de saída gerado com sucesso:", posArgs[1])
+	if opts.DryRun {
+		fmt.Println("Auditoria em modo -dry-run concluída com sucesso.")
+	} else {
+		fmt.Println("Arquivo de saída gerado com sucesso:", posArgs[1])
+	}
 }
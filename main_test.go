@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Garante que o placeholder do backend Vault, que usa '#' para separar o
+// path do campo (ex.: {{vault:secret/data/app#password}}), seja reconhecido
+// por placeholderRegex. Esta sintaxe deixou de funcionar quando '#' não
+// constava na classe de caracteres do identificador.
+func TestPlaceholderRegexMatchesVaultSyntax(t *testing.T) {
+	line := "PASSWORD={{vault:secret/data/app#password}}"
+
+	matches := placeholderRegex.FindStringSubmatch(line)
+	if matches == nil {
+		t.Fatalf("placeholderRegex não casou com %q", line)
+	}
+
+	if got, want := matches[1], "vault:secret/data/app#password"; got != want {
+		t.Errorf("identificador capturado = %q, esperado %q", got, want)
+	}
+}
+
+// Garante que uma linha cujo placeholder é satisfeito por uma variável de
+// ambiente produza apenas o registro de auditoria "env", sem um registro
+// "secretsmanager" fantasma para o mesmo placeholder.
+func TestEmitDryRunDoesNotDuplicateEnvSatisfiedPlaceholderAsSecret(t *testing.T) {
+	t.Setenv("DB_HOST", "localhost")
+
+	lines := []string{"DB_HOST=${DB_HOST}"}
+	results := map[secretLookupKey]secretLookupResult{}
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	if err := emitDryRun(writer, lines, results); err != nil {
+		t.Fatalf("emitDryRun retornou erro: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("erro ao descarregar o buffer: %v", err)
+	}
+
+	var records []dryRunRecord
+	decoder := json.NewDecoder(strings.NewReader(buf.String()))
+	for decoder.More() {
+		var record dryRunRecord
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("erro ao decodificar registro de auditoria: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 1 {
+		t.Fatalf("esperado exatamente 1 registro, obtidos %d: %+v", len(records), records)
+	}
+	if records[0].Backend != "env" {
+		t.Errorf("backend do registro = %q, esperado %q", records[0].Backend, "env")
+	}
+	for _, record := range records {
+		if record.Backend == "secretsmanager" {
+			t.Errorf("registro fantasma de secretsmanager emitido para placeholder satisfeito por env: %+v", record)
+		}
+	}
+}
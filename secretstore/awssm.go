@@ -0,0 +1,20 @@
+// Este arquivo adapta o awsclient.AWSClient à interface SecretProvider,
+// preservando o comportamento histórico de resolução via AWS Secrets
+// Manager (cache por identificador e sem novas tentativas após falha).
+package secretstore
+
+import "envconfig/awsclient"
+
+// AWSSecretsManagerProvider resolve segredos através do AWS Secrets Manager.
+type AWSSecretsManagerProvider struct {
+	client *awsclient.AWSClient
+}
+
+// NewAWSSecretsManagerProvider cria um provider apoiado no AWSClient informado.
+func NewAWSSecretsManagerProvider(client *awsclient.AWSClient) *AWSSecretsManagerProvider {
+	return &AWSSecretsManagerProvider{client: client}
+}
+
+func (p *AWSSecretsManagerProvider) GetSecret(id string) (string, error) {
+	return p.client.GetSecret(id)
+}
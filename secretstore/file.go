@@ -0,0 +1,48 @@
+// Este arquivo implementa um SecretProvider que lê o valor do segredo
+// diretamente de um arquivo local, útil para segredos montados via volumes
+// (ex. Kubernetes Secrets, Docker secrets).
+package secretstore
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FileProvider resolve segredos lendo o conteúdo de um arquivo local, com
+// cache por identificador e sem novas tentativas após falha, nos mesmos
+// moldes dos demais providers. O identificador é o caminho do arquivo; as
+// quebras de linha ("\n") finais, se houver, são descartadas.
+type FileProvider struct {
+	cache      map[string]cacheItem
+	cacheMutex sync.Mutex
+}
+
+// NewFileProvider cria um provider de arquivos locais.
+func NewFileProvider() *FileProvider {
+	return &FileProvider{
+		cache: make(map[string]cacheItem),
+	}
+}
+
+func (p *FileProvider) GetSecret(id string) (string, error) {
+	p.cacheMutex.Lock()
+	defer p.cacheMutex.Unlock()
+
+	if item, exists := p.cache[id]; exists {
+		return item.value, item.err
+	}
+
+	item := cacheItem{}
+
+	data, err := os.ReadFile(id)
+	if err != nil {
+		item.err = fmt.Errorf("não foi possível ler o arquivo de segredo '%s'. %w", id, err)
+	} else {
+		item.value = strings.TrimRight(string(data), "\n")
+	}
+
+	p.cache[id] = item
+	return item.value, item.err
+}
@@ -0,0 +1,50 @@
+// Package secretstore define uma interface comum para os diferentes backends
+// de segredos suportados (AWS Secrets Manager, SSM Parameter Store,
+// HashiCorp Vault, arquivos locais) e um registro que despacha cada
+// identificador {{...}} para o backend correto a partir de seu prefixo.
+package secretstore
+
+import "strings"
+
+// SecretProvider é implementado por cada backend de segredos suportado.
+type SecretProvider interface {
+	GetSecret(id string) (string, error)
+}
+
+// Registry despacha a resolução de um identificador para o SecretProvider
+// registrado sob seu prefixo (ex. "ssm:/prod/db/password" é resolvido pelo
+// provider registrado como "ssm", recebendo "/prod/db/password").
+// Identificadores sem prefixo reconhecido são resolvidos pelo provider
+// padrão, preservando o comportamento histórico de usar diretamente o AWS
+// Secrets Manager.
+type Registry struct {
+	providers       map[string]SecretProvider
+	defaultProvider SecretProvider
+}
+
+// NewRegistry cria um Registry vazio, usando defaultProvider para resolver
+// identificadores sem prefixo reconhecido.
+func NewRegistry(defaultProvider SecretProvider) *Registry {
+	return &Registry{
+		providers:       make(map[string]SecretProvider),
+		defaultProvider: defaultProvider,
+	}
+}
+
+// Register associa um backend de segredos a um prefixo de identificador.
+func (r *Registry) Register(prefix string, provider SecretProvider) {
+	r.providers[prefix] = provider
+}
+
+// GetSecret resolve o identificador através do backend correspondente ao seu
+// prefixo (texto antes do primeiro ':'), ou através do provider padrão caso
+// não haja prefixo reconhecido — o que preserva identificadores como ARNs
+// (que também contêm ':') funcionando como antes.
+func (r *Registry) GetSecret(identifier string) (string, error) {
+	if idx := strings.Index(identifier, ":"); idx > 0 {
+		if provider, exists := r.providers[identifier[:idx]]; exists {
+			return provider.GetSecret(identifier[idx+1:])
+		}
+	}
+	return r.defaultProvider.GetSecret(identifier)
+}
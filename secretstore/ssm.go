@@ -0,0 +1,115 @@
+// Este arquivo implementa um SecretProvider apoiado no AWS Systems Manager
+// Parameter Store.
+package secretstore
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+type cacheItem struct {
+	value string
+	err   error
+}
+
+// SSMProvider resolve segredos através do SSM Parameter Store, com cache por
+// identificador e sem novas tentativas após falha, nos mesmos moldes do
+// provider do Secrets Manager. O cache é protegido por cacheMutex apenas
+// durante o acesso ao mapa; a consulta em si é serializada por
+// cacheKeyLocks, para que uma consulta em andamento não bloqueie a leitura
+// de outros identificadores.
+type SSMProvider struct {
+	profile string
+	region  string
+
+	sessionOnce sync.Once
+	client      *ssm.SSM
+	sessionErr  error
+
+	cache         map[string]cacheItem
+	cacheMutex    sync.RWMutex
+	cacheKeyLocks keyedMutex
+}
+
+// NewSSMProvider cria um provider usando o perfil e a região informados. A
+// sessão AWS só é criada na primeira chamada a GetSecret.
+func NewSSMProvider(profile, region string) *SSMProvider {
+	return &SSMProvider{
+		profile: profile,
+		region:  region,
+		cache:   make(map[string]cacheItem),
+	}
+}
+
+// lookupCache consulta o cache de segredos já resolvidos.
+func (p *SSMProvider) lookupCache(id string) (cacheItem, bool) {
+	p.cacheMutex.RLock()
+	defer p.cacheMutex.RUnlock()
+
+	item, exists := p.cache[id]
+	return item, exists
+}
+
+func (p *SSMProvider) getClient() (*ssm.SSM, error) {
+	p.sessionOnce.Do(func() {
+		sessionOptions := session.Options{Profile: p.profile}
+		if p.region == "" {
+			sessionOptions.SharedConfigState = session.SharedConfigEnable
+		} else {
+			sessionOptions.Config = aws.Config{Region: aws.String(p.region)}
+		}
+
+		sess, err := session.NewSessionWithOptions(sessionOptions)
+		if err != nil {
+			p.sessionErr = fmt.Errorf("não foi possível criar sessão para o SSM Parameter Store. %w", err)
+			return
+		}
+
+		p.client = ssm.New(sess)
+	})
+	return p.client, p.sessionErr
+}
+
+// Retorna o valor de um parâmetro do SSM Parameter Store, sempre com
+// WithDecryption habilitado para suportar parâmetros SecureString.
+func (p *SSMProvider) GetSecret(id string) (string, error) {
+	if item, found := p.lookupCache(id); found {
+		return item.value, item.err
+	}
+
+	unlock := p.cacheKeyLocks.Lock(id)
+	defer unlock()
+
+	// Outra goroutine pode ter resolvido este identificador enquanto
+	// aguardávamos o lock específico dele.
+	if item, found := p.lookupCache(id); found {
+		return item.value, item.err
+	}
+
+	item := cacheItem{}
+
+	client, err := p.getClient()
+	if err != nil {
+		item.err = err
+	} else {
+		output, err := client.GetParameter(&ssm.GetParameterInput{
+			Name:           aws.String(id),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			item.err = fmt.Errorf("não foi possível obter o parâmetro '%s' no SSM Parameter Store. %w", id, err)
+		} else {
+			item.value = aws.StringValue(output.Parameter.Value)
+		}
+	}
+
+	p.cacheMutex.Lock()
+	p.cache[id] = item
+	p.cacheMutex.Unlock()
+
+	return item.value, item.err
+}
@@ -0,0 +1,126 @@
+// Este arquivo implementa um SecretProvider para o HashiCorp Vault, falando
+// diretamente com a API HTTP (suporta KV v1 e v2), configurado através das
+// variáveis de ambiente VAULT_ADDR e VAULT_TOKEN.
+package secretstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+type vaultResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// VaultProvider resolve segredos no HashiCorp Vault. O identificador segue o
+// formato "<path>#<campo>", ex. "secret/data/app#password" para um mount KV
+// v2 ou "secret/app#password" para KV v1. O cache é protegido por
+// cacheMutex apenas durante o acesso ao mapa; a consulta em si é serializada
+// por cacheKeyLocks, para que uma consulta em andamento não bloqueie a
+// leitura de outros identificadores.
+type VaultProvider struct {
+	addr       string
+	token      string
+	httpClient *http.Client
+
+	cache         map[string]cacheItem
+	cacheMutex    sync.RWMutex
+	cacheKeyLocks keyedMutex
+}
+
+// NewVaultProvider cria um provider a partir de VAULT_ADDR e VAULT_TOKEN. A
+// ausência de qualquer uma delas só resulta em erro na primeira chamada a
+// GetSecret, para não impedir o uso dos demais backends.
+func NewVaultProvider() *VaultProvider {
+	return &VaultProvider{
+		addr:       strings.TrimRight(os.Getenv("VAULT_ADDR"), "/"),
+		token:      os.Getenv("VAULT_TOKEN"),
+		httpClient: &http.Client{},
+		cache:      make(map[string]cacheItem),
+	}
+}
+
+// lookupCache consulta o cache de segredos já resolvidos.
+func (p *VaultProvider) lookupCache(id string) (cacheItem, bool) {
+	p.cacheMutex.RLock()
+	defer p.cacheMutex.RUnlock()
+
+	item, exists := p.cache[id]
+	return item, exists
+}
+
+func (p *VaultProvider) GetSecret(id string) (string, error) {
+	if item, found := p.lookupCache(id); found {
+		return item.value, item.err
+	}
+
+	unlock := p.cacheKeyLocks.Lock(id)
+	defer unlock()
+
+	// Outra goroutine pode ter resolvido este identificador enquanto
+	// aguardávamos o lock específico dele.
+	if item, found := p.lookupCache(id); found {
+		return item.value, item.err
+	}
+
+	item := cacheItem{}
+	if p.addr == "" || p.token == "" {
+		item.err = fmt.Errorf("VAULT_ADDR e VAULT_TOKEN precisam estar definidos para usar o backend vault")
+	} else if path, field, ok := strings.Cut(id, "#"); !ok {
+		item.err = fmt.Errorf("identificador vault '%s' inválido. Esperado <path>#<campo>", id)
+	} else {
+		item.value, item.err = p.fetch(path, field)
+	}
+
+	p.cacheMutex.Lock()
+	p.cache[id] = item
+	p.cacheMutex.Unlock()
+
+	return item.value, item.err
+}
+
+func (p *VaultProvider) fetch(path, field string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, p.addr+"/v1/"+path, nil)
+	if err != nil {
+		return "", fmt.Errorf("não foi possível montar a requisição ao Vault para '%s'. %w", path, err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("não foi possível consultar o Vault para '%s'. %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("o Vault retornou status %d para '%s'", resp.StatusCode, path)
+	}
+
+	var parsed vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", fmt.Errorf("não foi possível interpretar a resposta do Vault para '%s'. %w", path, err)
+	}
+
+	// KV v2 aninha o conteúdo do segredo em data.data; KV v1 o expõe
+	// diretamente em data.
+	data := parsed.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	value, exists := data[field]
+	if !exists {
+		return "", fmt.Errorf("campo '%s' não encontrado no segredo '%s' do Vault", field, path)
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("campo '%s' do segredo '%s' no Vault não é uma string", field, path)
+	}
+
+	return str, nil
+}